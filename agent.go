@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// caniuseDataURL is the caniuse "fulldata" feed, which (among everything
+// else) tracks global usage share per browser version.
+const caniuseDataURL = "https://raw.githubusercontent.com/Fyrd/caniuse/master/fulldata-json/data-2.0.json"
+
+// agentCacheTTL is how long a cached agent pool is considered fresh.
+const agentCacheTTL = 24 * time.Hour
+
+// topAgentVersionsPerBrowser is how many of the most-used versions of each
+// browser are kept when building the synthesized pool.
+const topAgentVersionsPerBrowser = 10
+
+// weightedUserAgent is one synthesized User-Agent string plus the global
+// usage share of the browser version it was built from, and the agent-mix
+// category it belongs to (used by -agent-mix filtering).
+type weightedUserAgent struct {
+	UserAgent string  `json:"user_agent"`
+	Weight    float64 `json:"weight"`
+	Category  string  `json:"category"`
+}
+
+// agentCacheFile is the on-disk cache format written to
+// ~/.byteseeker/agents.json.
+type agentCacheFile struct {
+	FetchedAt time.Time           `json:"fetched_at"`
+	Agents    []weightedUserAgent `json:"agents"`
+}
+
+// caniuseData is the subset of the caniuse feed this package cares about.
+type caniuseData struct {
+	Agents map[string]struct {
+		UsageGlobal map[string]float64 `json:"usage_global"`
+	} `json:"agents"`
+}
+
+// browserVersionShare is one browser version and its global usage share.
+type browserVersionShare struct {
+	version string
+	share   float64
+}
+
+// platformTokens are the OS/platform fragments swapped into a browser's UA
+// template, grouped by the -agent-mix category they represent.
+var platformTokens = map[string][]string{
+	"chrome": {
+		"Windows NT 10.0; Win64; x64",
+		"Windows NT 11.0; Win64; x64",
+		"Macintosh; Intel Mac OS X 13_6",
+		"Macintosh; Intel Mac OS X 14_4",
+		"X11; Linux x86_64",
+	},
+	"firefox": {
+		"Windows NT 10.0; Win64; x64",
+		"Windows NT 11.0; Win64; x64",
+		"Macintosh; Intel Mac OS X 13.6",
+		"X11; Ubuntu; Linux x86_64",
+	},
+	"mobile": {
+		"Linux; Android 13; Pixel 7",
+		"Linux; Android 14; SM-S918B",
+		"iPhone; CPU iPhone OS 17_4 like Mac OS X",
+	},
+}
+
+// agentCachePath returns ~/.byteseeker/agents.json, creating the parent
+// directory if needed.
+func agentCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".byteseeker")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "agents.json"), nil
+}
+
+// loadCachedAgentPool reads the on-disk agent pool if present and still
+// within agentCacheTTL.
+func loadCachedAgentPool() ([]weightedUserAgent, error) {
+	path, err := agentCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache agentCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+
+	if time.Since(cache.FetchedAt) > agentCacheTTL {
+		return nil, fmt.Errorf("cached agent pool is stale")
+	}
+
+	return cache.Agents, nil
+}
+
+// saveCachedAgentPool writes pool to ~/.byteseeker/agents.json.
+func saveCachedAgentPool(pool []weightedUserAgent) error {
+	path, err := agentCachePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(agentCacheFile{FetchedAt: time.Now(), Agents: pool}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// fetchBrowserShares downloads the caniuse feed and returns the top
+// topAgentVersionsPerBrowser versions (by global usage share) for each of
+// "chrome" and "firefox".
+func fetchBrowserShares() (map[string][]browserVersionShare, error) {
+	req, err := http.NewRequest(http.MethodGet, caniuseDataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("caniuse feed returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed caniuseData
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, err
+	}
+
+	shares := make(map[string][]browserVersionShare)
+	for _, browser := range []string{"chrome", "firefox"} {
+		agent, ok := parsed.Agents[browser]
+		if !ok {
+			continue
+		}
+
+		versions := make([]browserVersionShare, 0, len(agent.UsageGlobal))
+		for version, share := range agent.UsageGlobal {
+			versions = append(versions, browserVersionShare{version: version, share: share})
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i].share > versions[j].share })
+
+		if len(versions) > topAgentVersionsPerBrowser {
+			versions = versions[:topAgentVersionsPerBrowser]
+		}
+		shares[browser] = versions
+	}
+
+	return shares, nil
+}
+
+// synthesizeAgentPool combines browser version/share data with the platform
+// token templates into a weighted pool of User-Agent strings.
+func synthesizeAgentPool(shares map[string][]browserVersionShare) []weightedUserAgent {
+	var pool []weightedUserAgent
+
+	for _, v := range shares["chrome"] {
+		for _, platform := range platformTokens["chrome"] {
+			pool = append(pool, weightedUserAgent{
+				UserAgent: fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Safari/537.36", platform, v.version),
+				Weight:    v.share,
+				Category:  "chrome",
+			})
+		}
+		for _, platform := range platformTokens["mobile"] {
+			if !strings.Contains(platform, "Android") {
+				continue
+			}
+			pool = append(pool, weightedUserAgent{
+				UserAgent: fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Mobile Safari/537.36", platform, v.version),
+				Weight:    v.share,
+				Category:  "mobile",
+			})
+		}
+	}
+
+	for _, v := range shares["firefox"] {
+		for _, platform := range platformTokens["firefox"] {
+			pool = append(pool, weightedUserAgent{
+				UserAgent: fmt.Sprintf("Mozilla/5.0 (%s; rv:%s) Gecko/20100101 Firefox/%s", platform, v.version, v.version),
+				Weight:    v.share,
+				Category:  "firefox",
+			})
+		}
+	}
+
+	for _, platform := range platformTokens["mobile"] {
+		if strings.Contains(platform, "iPhone") {
+			pool = append(pool, weightedUserAgent{
+				UserAgent: fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", platform),
+				Weight:    1,
+				Category:  "mobile",
+			})
+		}
+	}
+
+	return pool
+}
+
+// loadAgentPool returns a weighted User-Agent pool, preferring a fresh
+// on-disk cache, then the live caniuse feed, and finally falling back to nil
+// (the caller should fall back to defaultUserAgents) if both are unavailable.
+// Passing refresh forces a live fetch even if a fresh cache exists.
+func loadAgentPool(refresh bool) []weightedUserAgent {
+	if !refresh {
+		if pool, err := loadCachedAgentPool(); err == nil && len(pool) > 0 {
+			return pool
+		}
+	}
+
+	shares, err := fetchBrowserShares()
+	if err != nil {
+		return nil
+	}
+
+	pool := synthesizeAgentPool(shares)
+	if len(pool) == 0 {
+		return nil
+	}
+
+	if err := saveCachedAgentPool(pool); err != nil {
+		fmt.Printf("Warning: failed to cache agent pool: %s\n", err)
+	}
+
+	return pool
+}
+
+// filterAgentPool keeps only the agents whose category is in mix. An empty
+// mix leaves the pool untouched.
+func filterAgentPool(pool []weightedUserAgent, mix []string) []weightedUserAgent {
+	if len(mix) == 0 {
+		return pool
+	}
+
+	allowed := make(map[string]bool, len(mix))
+	for _, category := range mix {
+		allowed[strings.TrimSpace(strings.ToLower(category))] = true
+	}
+
+	var filtered []weightedUserAgent
+	for _, a := range pool {
+		if allowed[a.Category] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// pickWeightedUserAgent selects a User-Agent from pool at random, weighted
+// by each entry's global usage share. It falls back to a uniform pick from
+// fallback (the baked-in defaultUserAgents list) when pool is empty.
+func pickWeightedUserAgent(pool []weightedUserAgent, fallback []string) string {
+	if len(pool) == 0 {
+		return fallback[rand.Intn(len(fallback))]
+	}
+
+	var total float64
+	for _, a := range pool {
+		total += a.Weight
+	}
+	if total <= 0 {
+		return pool[rand.Intn(len(pool))].UserAgent
+	}
+
+	target := rand.Float64() * total
+	var cumulative float64
+	for _, a := range pool {
+		cumulative += a.Weight
+		if target <= cumulative {
+			return a.UserAgent
+		}
+	}
+	return pool[len(pool)-1].UserAgent
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// calibrationProbeCount is how many random high-entropy paths are requested
+// against a host before scanning begins.
+const calibrationProbeCount = 4
+
+// calibrationBodySampleSize bounds how much of a calibration response body is
+// read when computing its simhash.
+const calibrationBodySampleSize = 64 * 1024
+
+// hostBaseline captures what a host's "soft-404" wildcard response looks
+// like, so real responses can be compared against it during the scan.
+type hostBaseline struct {
+	statusCode    int
+	contentLength int64
+	bodyHash      uint64
+}
+
+// softErrorPhrases are high-signal phrases that show up in wildcard/soft-404
+// pages across a few common locales.
+var softErrorPhrases = []string{
+	"not found",
+	"does not exist",
+	"no longer available",
+	"page not found",
+	"página no encontrada",
+	"no se encontró",
+	"seite nicht gefunden",
+	"page introuvable",
+}
+
+// randomHighEntropyPath returns a 20-char hex string unlikely to collide with
+// a real path on the target.
+func randomHighEntropyPath() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// simhash produces a cheap 64-bit locality-sensitive hash of body, so two
+// near-identical bodies (e.g. a wildcard page with a timestamp in it) end up
+// a small Hamming distance apart instead of completely different.
+func simhash(body []byte) uint64 {
+	var weights [64]int
+	for _, field := range strings.Fields(strings.ToLower(string(body))) {
+		h := fnv64(field)
+		for bit := 0; bit < 64; bit++ {
+			if h&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var out uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			out |= 1 << uint(bit)
+		}
+	}
+	return out
+}
+
+// fnv64 is a tiny FNV-1a implementation used as simhash's token hash.
+func fnv64(s string) uint64 {
+	const offset64 = 14695981039346656037
+	const prime64 = 1099511628211
+
+	h := uint64(offset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+	return h
+}
+
+// hammingDistance64 counts the differing bits between two simhashes.
+func hammingDistance64(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// looksLikeSoftErrorBody does a cheap keyword scan over a response body,
+// used to boost soft-404 confidence for hosts that return a 200 with a
+// human-readable "not found" message instead of a real 404.
+func looksLikeSoftErrorBody(body []byte) bool {
+	lower := strings.ToLower(string(body))
+	for _, phrase := range softErrorPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// calibrateHost probes baseURL with a handful of random high-entropy paths
+// and returns the resulting baseline describing that host's wildcard
+// response. It returns a nil baseline (and no error) if the probes disagree
+// with each other, since that means the host doesn't have a consistent
+// soft-404 page to compare against.
+func calibrateHost(client *http.Client, baseURL string, userAgents []string) (*hostBaseline, error) {
+	var baselines []hostBaseline
+
+	for i := 0; i < calibrationProbeCount; i++ {
+		probePath, err := randomHighEntropyPath()
+		if err != nil {
+			return nil, fmt.Errorf("generating calibration path: %w", err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/"+probePath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("building calibration request: %w", err)
+		}
+		req.Header.Set("User-Agent", userAgents[i%len(userAgents)])
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// A single network hiccup shouldn't block the whole scan; just
+			// skip this probe.
+			continue
+		}
+
+		body := readBoundedBody(resp.Body, calibrationBodySampleSize)
+		baselines = append(baselines, hostBaseline{
+			statusCode:    resp.StatusCode,
+			contentLength: int64(len(body)),
+			bodyHash:      simhash(body),
+		})
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if len(baselines) == 0 {
+		return nil, fmt.Errorf("all calibration probes failed")
+	}
+
+	reference := baselines[0]
+	for _, b := range baselines[1:] {
+		if b.statusCode != reference.statusCode || hammingDistance64(b.bodyHash, reference.bodyHash) > 4 {
+			// Inconsistent wildcard responses: nothing stable to compare
+			// against, so skip soft-404 filtering for this host.
+			return nil, nil
+		}
+	}
+
+	return &reference, nil
+}
+
+// isSoftMatch reports whether a real response matches the host's calibrated
+// soft-404 baseline closely enough to be dropped as noise.
+func isSoftMatch(baseline *hostBaseline, statusCode int, contentLength int64, bodyHash uint64, similarityThreshold int) bool {
+	if baseline == nil {
+		return false
+	}
+	if statusCode != baseline.statusCode {
+		return false
+	}
+	if baseline.contentLength > 0 {
+		delta := float64(contentLength-baseline.contentLength) / float64(baseline.contentLength)
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta > 0.05 {
+			return false
+		}
+	}
+	return hammingDistance64(bodyHash, baseline.bodyHash) <= similarityThreshold
+}
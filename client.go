@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Requester abstracts the HTTP engine scanTarget talks to, so the scanner
+// isn't tied to net/http. The default implementation wraps a shared
+// net/http.Client; a fasthttp-backed implementation is available behind
+// the "fasthttp" build tag and the -engine flag.
+type Requester interface {
+	Do(ctx context.Context, method, url string, headers map[string]string) (status int, header http.Header, body io.ReadCloser, err error)
+}
+
+// httpRequester is the default Requester. It reuses a single shared
+// net/http.Client with keep-alives enabled, instead of the old pattern of
+// building a fresh *http.Client (with DisableKeepAlives) on every request,
+// which forced a new TCP+TLS handshake per path.
+type httpRequester struct {
+	client *http.Client
+}
+
+func newHTTPRequester(client *http.Client) *httpRequester {
+	return &httpRequester{client: client}
+}
+
+func (r *httpRequester) Do(ctx context.Context, method, requestURL string, headers map[string]string) (int, http.Header, io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, resp.Body, nil
+}
+
+// newEngineRequester builds the Requester selected by config.engine.
+// fasthttp support is only compiled in when built with `-tags fasthttp`;
+// requesting it from a default build falls back to net/http with a warning.
+func newEngineRequester(config ScannerConfig, client *http.Client, dnsCache *dnsCache) Requester {
+	if config.engine == "fasthttp" {
+		if requester, ok := newFasthttpRequester(config.timeout, dnsCache); ok {
+			return requester
+		}
+		fmt.Println("Warning: -engine fasthttp requires building with `-tags fasthttp`; falling back to net/http")
+	}
+	return newHTTPRequester(client)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkScan_ReuseConn measures httpRequester.Do against a keep-alive
+// server, in the style of fasthttp's TestAllocationClient: it asserts the
+// shared-client path reuses one connection instead of paying a new
+// TCP+TLS handshake per request, the cost the old per-request *http.Client
+// pattern used to incur.
+func BenchmarkScan_ReuseConn(b *testing.B) {
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := server.Client()
+	requester := newHTTPRequester(client)
+	headers := map[string]string{"User-Agent": "bench"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		status, _, body, err := requester.Do(context.Background(), http.MethodGet, server.URL, headers)
+		if err != nil {
+			b.Fatalf("Do: %s", err)
+		}
+		io.Copy(io.Discard, body)
+		body.Close()
+		if status != http.StatusOK {
+			b.Fatalf("unexpected status %d", status)
+		}
+	}
+	if n := atomic.LoadInt64(&conns); n != 1 {
+		b.Fatalf("opened %d connections for %d requests, want 1", n, b.N)
+	}
+}
@@ -26,12 +26,22 @@ var defaultUserAgents = []string{
 }
 
 type ScannerConfig struct {
-	baseURL       string
-	pathsFile     string
-	concurrent    int
-	timeout       time.Duration
-	userAgents    []string
-	adaptiveDelay time.Duration
-	outputFormat  string
-	outputFile    string
+	baseURL             string
+	pathsFile           string
+	concurrent          int
+	timeout             time.Duration
+	userAgents          []string
+	outputFormat        string
+	outputFile          string
+	calibrate           bool
+	similarityThreshold int
+	agentPool           []weightedUserAgent
+	rps                 float64
+	burst               int
+	maxBodySize         int64
+	recursive           bool
+	recursionDepth      int
+	recursionStatuses   map[int]bool
+	engine              string
+	http2               bool
 }
@@ -58,3 +58,9 @@ func (c *dnsCache) cachedDialContext(ctx context.Context, network, addr string)
 	dialer := net.Dialer{}
 	return dialer.DialContext(ctx, network, addr)
 }
+
+// cachedDial is cachedDialContext without a context, for HTTP clients (like
+// fasthttp's) whose dial hook doesn't take one.
+func (c *dnsCache) cachedDial(network, addr string) (net.Conn, error) {
+	return c.cachedDialContext(context.Background(), network, addr)
+}
@@ -0,0 +1,68 @@
+//go:build fasthttp
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// fasthttpRequester is a Requester backed by valyala/fasthttp, selected via
+// `-engine fasthttp` (and building with `-tags fasthttp`) for substantially
+// higher throughput than net/http under heavy concurrency.
+type fasthttpRequester struct {
+	client *fasthttp.Client
+}
+
+func newFasthttpRequester(timeout time.Duration, dnsCache *dnsCache) (Requester, bool) {
+	return &fasthttpRequester{
+		client: &fasthttp.Client{
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+			Dial: func(addr string) (net.Conn, error) {
+				return dnsCache.cachedDial("tcp", addr)
+			},
+		},
+	}, true
+}
+
+func (r *fasthttpRequester) Do(ctx context.Context, method, requestURL string, headers map[string]string) (int, http.Header, io.ReadCloser, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(requestURL)
+	req.Header.SetMethod(method)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	var err error
+	if hasDeadline {
+		err = r.client.DoDeadline(req, resp, deadline)
+	} else {
+		err = r.client.Do(req, resp)
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	header := make(http.Header)
+	resp.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+
+	// resp's buffers are returned to fasthttp's pool when this function
+	// returns, so the body has to be copied out before then.
+	body := append([]byte(nil), resp.Body()...)
+
+	return resp.StatusCode(), header, io.NopCloser(bytes.NewReader(body)), nil
+}
@@ -0,0 +1,12 @@
+//go:build !fasthttp
+
+package main
+
+import "time"
+
+// newFasthttpRequester is stubbed out in default builds, which don't pull
+// in the fasthttp dependency. Build with `-tags fasthttp` to get the real
+// implementation in fasthttp_client.go.
+func newFasthttpRequester(timeout time.Duration, dnsCache *dnsCache) (Requester, bool) {
+	return nil, false
+}
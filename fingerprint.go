@@ -0,0 +1,244 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed fingerprints.json
+var fingerprintsData []byte
+
+// techSignature is one entry of the embedded fingerprints.json, in the
+// Wappalyzer-style shape: a body regex and/or a set of header-name ->
+// regex patterns. A pattern's first capture group, if any, is treated as
+// the detected version.
+type techSignature struct {
+	Body    string            `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type compiledSignature struct {
+	name    string
+	body    *regexp.Regexp
+	headers map[string]*regexp.Regexp
+}
+
+var compiledFingerprints = mustCompileFingerprints()
+
+// mustCompileFingerprints parses the embedded fingerprints.json once at
+// startup. A malformed fingerprints file is a build-time mistake, not a
+// runtime condition, so it panics rather than returning an error.
+func mustCompileFingerprints() []compiledSignature {
+	var raw map[string]techSignature
+	if err := json.Unmarshal(fingerprintsData, &raw); err != nil {
+		panic(fmt.Sprintf("invalid embedded fingerprints.json: %s", err))
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	compiled := make([]compiledSignature, 0, len(raw))
+	for _, name := range names {
+		sig := raw[name]
+		cs := compiledSignature{name: name}
+
+		if sig.Body != "" {
+			cs.body = regexp.MustCompile("(?i)" + sig.Body)
+		}
+		if len(sig.Headers) > 0 {
+			cs.headers = make(map[string]*regexp.Regexp, len(sig.Headers))
+			for header, pattern := range sig.Headers {
+				cs.headers[header] = regexp.MustCompile("(?i)" + pattern)
+			}
+		}
+
+		compiled = append(compiled, cs)
+	}
+	return compiled
+}
+
+// detectTechnologies matches the embedded fingerprints against a response's
+// headers and body, returning the distinct technology names it recognizes
+// (with a detected version appended, when the pattern captured one).
+func detectTechnologies(header http.Header, body []byte) []string {
+	var found []string
+	for _, sig := range compiledFingerprints {
+		if tech := matchSignature(sig, header, body); tech != "" {
+			found = append(found, tech)
+		}
+	}
+	return found
+}
+
+// matchSignature reports the detected tech (with version, if captured) for
+// sig, or "" if it doesn't match. Within the header set, every pattern must
+// match - e.g. Cloudflare's "Server" and "CF-RAY" entries are a single
+// compound signature, not two independent alternatives - so a lone "CF-RAY"
+// on some other CDN doesn't misreport it. But the header set and the body
+// pattern are themselves independent signals: WordPress/Drupal carry both a
+// header entry and a body entry, and most sites only expose one of the two
+// (no X-Powered-By/X-Generator header), so a signature matches if either
+// side matches on its own.
+func matchSignature(sig compiledSignature, header http.Header, body []byte) string {
+	if len(sig.headers) > 0 {
+		if match := matchAllHeaders(sig.headers, header); match != nil {
+			return formatTech(sig.name, match)
+		}
+	}
+	if sig.body != nil {
+		if m := sig.body.FindSubmatch(body); m != nil {
+			return formatTech(sig.name, byteMatchesToStrings(m))
+		}
+	}
+	return ""
+}
+
+// matchAllHeaders reports the last pattern's captured groups if every
+// header pattern in headers matches, or nil if any of them doesn't.
+func matchAllHeaders(headers map[string]*regexp.Regexp, header http.Header) []string {
+	var lastMatch []string
+	for name, re := range headers {
+		value := header.Get(name)
+		if value == "" {
+			return nil
+		}
+		m := re.FindStringSubmatch(value)
+		if m == nil {
+			return nil
+		}
+		lastMatch = m
+	}
+	return lastMatch
+}
+
+func formatTech(name string, match []string) string {
+	if len(match) > 1 && match[1] != "" {
+		return fmt.Sprintf("%s %s", name, match[1])
+	}
+	return name
+}
+
+func byteMatchesToStrings(matches [][]byte) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = string(m)
+	}
+	return out
+}
+
+// titleRegexp extracts the contents of an HTML document's <title> tag.
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// extractTitle returns the trimmed text of body's <title> tag, or "" if it
+// has none.
+func extractTitle(body []byte) string {
+	m := titleRegexp.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return collapseWhitespace(string(m[1]))
+}
+
+var whitespaceRunRegexp = regexp.MustCompile(`\s+`)
+
+func collapseWhitespace(s string) string {
+	return strings.TrimSpace(whitespaceRunRegexp.ReplaceAllString(s, " "))
+}
+
+// fetchFaviconHash requests baseURL's /favicon.ico and returns its mmh3
+// hash in the base64-then-murmur3 form popularized by Shodan/Censys, so
+// results are directly comparable against those databases. It returns ""
+// if the favicon can't be fetched.
+func fetchFaviconHash(client *http.Client, baseURL string) string {
+	resp, err := client.Get(baseURL + "/favicon.ico")
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	body := readBoundedBody(resp.Body, faviconMaxSize)
+	if len(body) == 0 {
+		return ""
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(body)
+	return fmt.Sprintf("%d", int32(murmur3_32([]byte(insertLineBreaks(encoded, 76)), 0)))
+}
+
+// faviconMaxSize bounds how much of a favicon response is read.
+const faviconMaxSize = 1 << 20
+
+// insertLineBreaks mimics Python's base64.encodestring, which Shodan/Censys
+// favicon hashing is built around: a newline every lineLength characters.
+func insertLineBreaks(s string, lineLength int) string {
+	var out []byte
+	for i := 0; i < len(s); i += lineLength {
+		end := i + lineLength
+		if end > len(s) {
+			end = len(s)
+		}
+		out = append(out, s[i:end]...)
+		out = append(out, '\n')
+	}
+	return string(out)
+}
+
+// murmur3_32 is a standard MurmurHash3 x86 32-bit implementation.
+func murmur3_32(data []byte, seed uint32) uint32 {
+	const c1 = 0xcc9e2d51
+	const c2 = 0x1b873593
+
+	h := seed
+	length := len(data)
+	nblocks := length / 4
+
+	for i := 0; i < nblocks; i++ {
+		k := uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+		k *= c1
+		k = (k << 15) | (k >> 17)
+		k *= c2
+
+		h ^= k
+		h = (h << 13) | (h >> 19)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := data[nblocks*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = (k << 15) | (k >> 17)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(length)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,9 +12,21 @@ func main() {
 	pathsFile := flag.String("paths", "", "File containing paths to scan")
 	concurrent := flag.Int("concurrent", 10, "Number of concurrent goroutines for scanning")
 	timeout := flag.Duration("timeout", 10*time.Second, "HTTP request timeout")
-	adaptiveDelay := flag.Duration("adaptiveDelay", 100*time.Millisecond, "Initial adaptive delay between requests")
-	outputFormat := flag.String("o", "text", "Output format: text, json, xml")
+	rps := flag.Float64("rps", 10, "Requests per second allowed per host")
+	burst := flag.Int("burst", 20, "Token bucket burst capacity per host")
+	outputFormat := flag.String("o", "text", "Output format: text, json, xml, csv, jsonl")
 	outputFilename := flag.String("f", "", "Filename to save the output results")
+	maxBodySize := flag.Int64("max-body-size", 2*1024*1024, "Max response body bytes read per request, for hashing/fingerprinting")
+	calibrate := flag.Bool("calibrate", true, "Probe the target with random paths before scanning to filter soft-404 responses")
+	noCalibrate := flag.Bool("no-calibrate", false, "Disable soft-404 calibration (overrides -calibrate)")
+	similarityThreshold := flag.Int("similarity-threshold", 4, "Max simhash Hamming distance for a response to be treated as a soft-404")
+	refreshAgents := flag.Bool("refresh-agents", false, "Force a fresh fetch of the User-Agent pool instead of using the on-disk cache")
+	agentMix := flag.String("agent-mix", "chrome,firefox,mobile", "Comma-separated categories to draw User-Agents from (chrome,firefox,mobile)")
+	recursive := flag.Bool("recursive", false, "Recurse into discovered directories using the same wordlist")
+	recursionDepth := flag.Int("recursion-depth", 2, "Max recursion hops past the initial wordlist when -recursive is set")
+	recursionStatus := flag.String("recursion-status", "200,301,403", "Comma-separated status codes that trigger recursion")
+	engine := flag.String("engine", "net/http", "HTTP engine to use: net/http or fasthttp (requires building with -tags fasthttp)")
+	http2Flag := flag.Bool("http2", true, "Attempt HTTP/2 when using the net/http engine")
 	flag.Parse()
 
 	if *baseURL == "" || *pathsFile == "" || *outputFilename == "" {
@@ -22,15 +35,31 @@ func main() {
 		return
 	}
 
+	if *noCalibrate {
+		*calibrate = false
+	}
+
+	agentPool := filterAgentPool(loadAgentPool(*refreshAgents), strings.Split(*agentMix, ","))
+
 	config := ScannerConfig{
-		baseURL:       *baseURL,
-		pathsFile:     *pathsFile,
-		concurrent:    *concurrent,
-		timeout:       *timeout,
-		userAgents:    defaultUserAgents,
-		adaptiveDelay: *adaptiveDelay,
-		outputFormat:  *outputFormat,
-		outputFile:    *outputFilename,
+		baseURL:             *baseURL,
+		pathsFile:           *pathsFile,
+		concurrent:          *concurrent,
+		timeout:             *timeout,
+		userAgents:          defaultUserAgents,
+		outputFormat:        *outputFormat,
+		outputFile:          *outputFilename,
+		calibrate:           *calibrate,
+		similarityThreshold: *similarityThreshold,
+		agentPool:           agentPool,
+		rps:                 *rps,
+		burst:               *burst,
+		maxBodySize:         *maxBodySize,
+		recursive:           *recursive,
+		recursionDepth:      *recursionDepth,
+		recursionStatuses:   parseRecursionStatuses(*recursionStatus),
+		engine:              *engine,
+		http2:               *http2Flag,
 	}
 
 	runScanner(config)
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var csvHeader = []string{
+	"url", "status", "status_code", "content_length", "content_type",
+	"title", "technologies", "favicon_hash", "body_hash", "error",
+}
+
+// resultWriter streams ScanResults to an output file as they complete,
+// instead of buffering the whole scan in memory. Used for the text, csv
+// and jsonl formats; json/xml are buffered separately by runScanner since
+// they need the full result set to produce one well-formed document.
+type resultWriter struct {
+	format string
+	w      io.Writer
+	csvW   *csv.Writer
+}
+
+func newResultWriter(format string, w io.Writer) *resultWriter {
+	rw := &resultWriter{format: format, w: w}
+	if format == "csv" {
+		rw.csvW = csv.NewWriter(w)
+		rw.csvW.Write(csvHeader)
+		rw.csvW.Flush()
+	}
+	return rw
+}
+
+func (rw *resultWriter) write(result ScanResult) error {
+	switch rw.format {
+	case "csv":
+		record := []string{
+			result.URL,
+			result.Status,
+			fmt.Sprintf("%d", result.StatusCode),
+			fmt.Sprintf("%d", result.ContentLength),
+			result.ContentType,
+			result.Title,
+			strings.Join(result.Technologies, "|"),
+			result.FaviconHash,
+			result.BodyHash,
+			result.ErrorMessage,
+		}
+		if err := rw.csvW.Write(record); err != nil {
+			return err
+		}
+		rw.csvW.Flush()
+		return rw.csvW.Error()
+
+	case "jsonl":
+		line, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		_, err = rw.w.Write(append(line, '\n'))
+		return err
+
+	default: // text
+		var line string
+		if result.ErrorMessage != "" {
+			line = result.ErrorMessage + "\n"
+		} else {
+			line = fmt.Sprintf("URL: %s, Status: %s, HTTP Status Code: %d, Title: %s, Technologies: %s\n",
+				result.URL, result.Status, result.StatusCode, result.Title, strings.Join(result.Technologies, ", "))
+		}
+		_, err := io.WriteString(rw.w, line)
+		return err
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a classic token bucket: it holds up to capacity tokens,
+// refills at refillRate tokens/sec, and can be paused (e.g. to honor a
+// Retry-After header) until pausedUntil.
+type tokenBucket struct {
+	mu            sync.Mutex
+	capacity      float64
+	tokens        float64
+	refillRate    float64
+	lastRefill    time.Time
+	pausedUntil   time.Time
+	healthyStreak int
+}
+
+// refill tops up the bucket based on how much time has passed since the
+// last refill. Callers must hold b.mu.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastRefill = now
+}
+
+// RateLimiter hands out one tokenBucket per host, so a slow/rate-limited
+// host backs off on its own without throttling requests to every other
+// host being scanned.
+type RateLimiter struct {
+	mu      sync.RWMutex
+	buckets map[string]*tokenBucket
+	rps     float64
+	burst   float64
+}
+
+// newRateLimiter builds a RateLimiter whose per-host buckets refill at rps
+// tokens/sec up to a capacity of burst tokens.
+func newRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rps:     rps,
+		burst:   float64(burst),
+	}
+}
+
+// bucketFor returns host's bucket, creating it on first use.
+func (r *RateLimiter) bucketFor(host string) *tokenBucket {
+	r.mu.RLock()
+	b, ok := r.buckets[host]
+	r.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b, ok := r.buckets[host]; ok {
+		return b
+	}
+
+	b = &tokenBucket{
+		capacity:   r.burst,
+		tokens:     r.burst,
+		refillRate: r.rps,
+		lastRefill: time.Now(),
+	}
+	r.buckets[host] = b
+	return b
+}
+
+// Wait blocks until host's bucket has a token to spend, a pause from a
+// prior Retry-After has elapsed, or ctx is done.
+func (r *RateLimiter) Wait(ctx context.Context, host string) error {
+	b := r.bucketFor(host)
+
+	for {
+		b.mu.Lock()
+		now := time.Now()
+
+		if now.Before(b.pausedUntil) {
+			wait := b.pausedUntil.Sub(now)
+			b.mu.Unlock()
+			if err := sleepOrDone(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.refill(now)
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		deficit := 1 - b.tokens
+		wait := time.Duration(deficit / b.refillRate * float64(time.Second))
+		b.mu.Unlock()
+		if err := sleepOrDone(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepOrDone sleeps for d, or returns early with ctx.Err() if ctx finishes first.
+func sleepOrDone(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Observe updates host's bucket based on a response: a 429/503 pauses the
+// bucket for the duration in its Retry-After header (if any) and halves its
+// refill rate, while a long streak of 2xx/3xx responses gradually restores
+// it. It's engine-agnostic (status code + headers only) so it works the
+// same whether the response came from net/http or fasthttp.
+func (r *RateLimiter) Observe(host string, statusCode int, header http.Header) {
+	b := r.bucketFor(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable:
+		if retryAfter := parseRetryAfter(header.Get("Retry-After")); retryAfter > 0 {
+			b.pausedUntil = time.Now().Add(retryAfter)
+		}
+		b.refillRate /= 2
+		if b.refillRate < 0.1 {
+			b.refillRate = 0.1
+		}
+		b.healthyStreak = 0
+	case statusCode < 400:
+		b.healthyStreak++
+		if b.healthyStreak >= 20 && b.refillRate < r.rps {
+			b.refillRate *= 1.1
+			if b.refillRate > r.rps {
+				b.refillRate = r.rps
+			}
+			b.healthyStreak = 0
+		}
+	default:
+		// Any other 4xx/5xx (404 chief among them, the dominant response in
+		// a directory-busting scan) is neutral: it's not evidence the host
+		// is struggling the way a 429/503 is, so it shouldn't reset the
+		// streak that the gradual-restore path above depends on.
+	}
+}
+
+// parseRetryAfter parses a Retry-After header in either its delta-seconds
+// or HTTP-date form, returning 0 if it can't be parsed or is already past.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
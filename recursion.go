@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// scanTask is one unit of work for the scanner: a path to request, and how
+// many recursion hops deep it is (the initial wordlist is depth 0, so
+// -recursion-depth N allows N hops past it, matching feroxbuster/gobuster's
+// --depth convention).
+type scanTask struct {
+	path  string
+	depth int
+}
+
+// taskQueue is a dynamically growing FIFO of scanTasks. Unlike a plain
+// channel, it supports workers enqueuing new tasks (discovered via
+// recursion) while other workers are still draining it, and closes itself
+// once the number of outstanding tasks - those pushed but not yet marked
+// done - reaches zero.
+type taskQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	items   []scanTask
+	pending int
+	closed  bool
+}
+
+func newTaskQueue() *taskQueue {
+	q := &taskQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds task to the queue and marks it as outstanding work.
+func (q *taskQueue) push(task scanTask) {
+	q.mu.Lock()
+	q.pending++
+	q.items = append(q.items, task)
+	q.cond.Signal()
+	q.mu.Unlock()
+}
+
+// pop blocks until a task is available or the queue has drained, returning
+// ok=false in the latter case.
+func (q *taskQueue) pop() (scanTask, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return scanTask{}, false
+	}
+
+	task := q.items[0]
+	q.items = q.items[1:]
+	return task, true
+}
+
+// taskDone marks a previously pushed task as fully processed (including any
+// follow-up tasks it pushed). Once every pushed task has been marked done,
+// the queue closes and pop starts returning ok=false.
+func (q *taskQueue) taskDone() {
+	q.mu.Lock()
+	q.pending--
+	if q.pending == 0 {
+		q.closed = true
+		q.cond.Broadcast()
+	}
+	q.mu.Unlock()
+}
+
+// closeEmpty closes the queue immediately, for the edge case of an empty
+// wordlist where no task is ever pushed to trigger the normal
+// pending-reaches-zero close in taskDone.
+func (q *taskQueue) closeEmpty() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// feederBegin reserves a pending slot on behalf of the caller that is about
+// to enqueue the initial wordlist. Without it, a worker could pop and
+// complete the first pushed task - driving pending back to zero - before the
+// feed loop has pushed the rest, closing the queue and silently dropping
+// whatever hadn't been pushed yet. feederEnd releases the slot once the feed
+// loop has pushed everything.
+func (q *taskQueue) feederBegin() {
+	q.mu.Lock()
+	q.pending++
+	q.mu.Unlock()
+}
+
+// feederEnd releases the slot reserved by feederBegin.
+func (q *taskQueue) feederEnd() {
+	q.taskDone()
+}
+
+// enqueueIfNew pushes task onto queue unless its path has already been
+// seen, preventing recursion cycles (e.g. a server that redirects every
+// subpath back onto itself).
+func enqueueIfNew(queue *taskQueue, visited *sync.Map, task scanTask) {
+	if _, loaded := visited.LoadOrStore(task.path, struct{}{}); loaded {
+		return
+	}
+	queue.push(task)
+}
+
+// directoryListingMarkers are phrases that show up in the generic
+// "Index of /" directory listing pages served by Apache/nginx autoindex.
+var directoryListingMarkers = []string{"index of /"}
+
+// looksLikeDirectory reports whether a response should be treated as a
+// directory for recursion purposes: its path ends in "/", or its body is
+// HTML and looks like an autoindex directory listing.
+func looksLikeDirectory(path, contentType string, body []byte) bool {
+	if strings.HasSuffix(path, "/") {
+		return true
+	}
+	if !strings.Contains(strings.ToLower(contentType), "text/html") {
+		return false
+	}
+	lower := strings.ToLower(string(body))
+	for _, marker := range directoryListingMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRecursionStatuses parses a "-recursion-status" flag value like
+// "200,301,403" into a status-code set.
+func parseRecursionStatuses(value string) map[int]bool {
+	statuses := make(map[int]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if code, err := strconv.Atoi(field); err == nil {
+			statuses[code] = true
+		}
+	}
+	return statuses
+}
+
+// childPath builds the path to enqueue when expanding dirPath with a word
+// from the original wordlist (e.g. "/admin" + "/login" -> "/admin/login").
+func childPath(dirPath, word string) string {
+	return strings.TrimRight(dirPath, "/") + "/" + strings.TrimPrefix(word, "/")
+}
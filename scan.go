@@ -2,76 +2,151 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type ScanResult struct {
-	URL          string `json:"url" xml:"url"`
-	StatusCode   int    `json:"status_code" xml:"status_code"`
-	Status       string `json:"status" xml:"status"`
-	ErrorMessage string `json:"error_message,omitempty" xml:"error_message,omitempty"`
+	URL           string   `json:"url" xml:"url"`
+	StatusCode    int      `json:"status_code" xml:"status_code"`
+	Status        string   `json:"status" xml:"status"`
+	ContentLength int64    `json:"content_length,omitempty" xml:"content_length,omitempty"`
+	ContentType   string   `json:"content_type,omitempty" xml:"content_type,omitempty"`
+	Title         string   `json:"title,omitempty" xml:"title,omitempty"`
+	Technologies  []string `json:"technologies,omitempty" xml:"technologies,omitempty"`
+	FaviconHash   string   `json:"favicon_hash,omitempty" xml:"favicon_hash,omitempty"`
+	BodyHash      string   `json:"body_hash,omitempty" xml:"body_hash,omitempty"`
+	ErrorMessage  string   `json:"error_message,omitempty" xml:"error_message,omitempty"`
 }
 
-func scanTarget(client *http.Client, baseURL, path string, wg *sync.WaitGroup, results chan<- ScanResult, userAgents []string, adaptiveDelay *time.Duration) {
-	defer wg.Done()
+// scanContext bundles the state shared by every scanTarget call for a given
+// run, so adding a new cross-cutting concern (rate limiting, calibration,
+// fingerprinting, ...) doesn't keep growing scanTarget's parameter list.
+type scanContext struct {
+	requester           Requester
+	timeout             time.Duration
+	baseURL             string
+	userAgents          []string
+	limiter             *RateLimiter
+	baseline            *hostBaseline
+	similarityThreshold int
+	agentPool           []weightedUserAgent
+	maxBodySize         int64
+	faviconHash         string
+	recursive           bool
+	recursionDepth      int
+	recursionStatuses   map[int]bool
+	wordlist            []string
+	queue               *taskQueue
+	visited             *sync.Map
+}
+
+func scanTarget(sc *scanContext, task scanTask, results chan<- ScanResult) {
+	userAgent := pickWeightedUserAgent(sc.agentPool, sc.userAgents)
 
-	userAgent := userAgents[rand.Intn(len(userAgents))]
+	host := sc.baseURL
+	if parsed, err := url.Parse(sc.baseURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
 
-	var resp *http.Response
-	var err error
+	var statusCode int
+	var header http.Header
+	var body io.ReadCloser
+	var lastErr error
+	var requested bool
 	for i := 0; i < 3; i++ {
-		ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+		ctx, cancel := context.WithTimeout(context.Background(), sc.timeout)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+path, nil)
-		if err != nil {
+		if waitErr := sc.limiter.Wait(ctx, host); waitErr != nil {
 			cancel()
-			if !strings.Contains(err.Error(), "404") {
-				results <- ScanResult{URL: baseURL + path, ErrorMessage: fmt.Sprintf("Error creating request for %s: %s\n", path, err)}
-			}
-			return
+			lastErr = waitErr
+			continue
 		}
-		req.Header.Set("User-Agent", userAgent)
 
-		resp, err = client.Do(req)
-		cancel()
-		if err == nil && resp != nil {
-			defer resp.Body.Close()
-			if resp.StatusCode != http.StatusNotFound {
-				break
-			}
+		respStatus, respHeader, respBody, doErr := sc.requester.Do(ctx, http.MethodGet, sc.baseURL+task.path, map[string]string{"User-Agent": userAgent})
+		if doErr != nil {
+			cancel()
+			lastErr = doErr
 			continue
 		}
-		time.Sleep(*adaptiveDelay)
-		*adaptiveDelay *= 2
+
+		// Only a successful Do updates statusCode/header/body, so a later
+		// failed retry can never stomp an earlier attempt's 404 response
+		// with a nil body.
+		requested = true
+		statusCode, header, body = respStatus, respHeader, respBody
+		sc.limiter.Observe(host, statusCode, header)
+		if statusCode != http.StatusNotFound {
+			// ctx stays live (cancelled by the defer) until the body is read.
+			break
+		}
+		// Drain the 404 body so the keep-alive connection can be reused.
+		readBoundedBody(body, sc.maxBodySize)
+		cancel()
 	}
 
-	if err != nil {
-		// Only send non-404 errors to the results channel
-		if !strings.Contains(err.Error(), "404") {
-			results <- ScanResult{URL: baseURL + path, ErrorMessage: fmt.Sprintf("Error scanning %s: %s\n", path, err)}
+	if !requested {
+		// Every attempt either errored or never got past limiter.Wait (e.g. a
+		// host paused longer than -timeout after a 429/503); either way there
+		// is no response to read.
+		if lastErr != nil && !strings.Contains(lastErr.Error(), "404") {
+			results <- ScanResult{URL: sc.baseURL + task.path, ErrorMessage: fmt.Sprintf("Error scanning %s: %s\n", task.path, lastErr)}
 		}
 		return
 	}
 
-	if resp != nil && resp.StatusCode != http.StatusNotFound {
+	if statusCode != http.StatusNotFound {
+		bodyBytes := readBoundedBody(body, sc.maxBodySize)
+		bodySimhash := simhash(bodyBytes)
+		contentType := header.Get("Content-Type")
+
+		if isSoftMatch(sc.baseline, statusCode, int64(len(bodyBytes)), bodySimhash, sc.similarityThreshold) {
+			return
+		}
+		if sc.baseline == nil && statusCode == http.StatusOK && looksLikeSoftErrorBody(bodyBytes) {
+			return
+		}
+
+		if sc.recursive && task.depth < sc.recursionDepth && sc.recursionStatuses[statusCode] && looksLikeDirectory(task.path, contentType, bodyBytes) {
+			for _, word := range sc.wordlist {
+				enqueueIfNew(sc.queue, sc.visited, scanTask{path: childPath(task.path, word), depth: task.depth + 1})
+			}
+		}
+
 		status := "Unknown"
-		switch resp.StatusCode {
+		switch statusCode {
 		case http.StatusOK:
 			status = "Found"
 		case http.StatusTooManyRequests:
 			status = "Rate limited"
 		}
-		results <- ScanResult{URL: baseURL + path, StatusCode: resp.StatusCode, Status: status}
+
+		bodySum := sha256.Sum256(bodyBytes)
+
+		results <- ScanResult{
+			URL:           sc.baseURL + task.path,
+			StatusCode:    statusCode,
+			Status:        status,
+			ContentLength: int64(len(bodyBytes)),
+			ContentType:   contentType,
+			Title:         extractTitle(bodyBytes),
+			Technologies:  detectTechnologies(header, bodyBytes),
+			FaviconHash:   sc.faviconHash,
+			BodyHash:      hex.EncodeToString(bodySum[:]),
+		}
 	}
 }
 
@@ -88,55 +163,118 @@ func runScanner(config ScannerConfig) {
 		MaxIdleConnsPerHost: 100,
 		IdleConnTimeout:     90 * time.Second,
 		DialContext:         dnsCache.cachedDialContext,
+		ForceAttemptHTTP2:   config.http2,
 	}
 	client := &http.Client{
 		Timeout:   config.timeout,
 		Transport: transport,
 	}
+	requester := newEngineRequester(config, client, dnsCache)
+
+	var baseline *hostBaseline
+	if config.calibrate {
+		baseline, err = calibrateHost(client, config.baseURL, config.userAgents)
+		if err != nil {
+			fmt.Printf("Calibration failed, scanning without a soft-404 baseline: %s\n", err)
+		}
+	}
+
+	queue := newTaskQueue()
+	var visited sync.Map
+
+	sc := &scanContext{
+		requester:           requester,
+		timeout:             config.timeout,
+		baseURL:             config.baseURL,
+		userAgents:          config.userAgents,
+		limiter:             newRateLimiter(config.rps, config.burst),
+		baseline:            baseline,
+		similarityThreshold: config.similarityThreshold,
+		agentPool:           config.agentPool,
+		maxBodySize:         config.maxBodySize,
+		faviconHash:         fetchFaviconHash(client, config.baseURL),
+		recursive:           config.recursive,
+		recursionDepth:      config.recursionDepth,
+		recursionStatuses:   config.recursionStatuses,
+		wordlist:            paths,
+		queue:               queue,
+		visited:             &visited,
+	}
 
-	var wg sync.WaitGroup
-	tasksChan := make(chan string, config.concurrent)
 	resultsChan := make(chan ScanResult, config.concurrent)
 
+	var scannedCount int64
+	var workers sync.WaitGroup
 	for i := 0; i < config.concurrent; i++ {
+		workers.Add(1)
 		go func() {
-			for path := range tasksChan {
-				wg.Add(1)
-				scanTarget(client, config.baseURL, path, &wg, resultsChan, config.userAgents, &config.adaptiveDelay)
+			defer workers.Done()
+			for {
+				task, ok := queue.pop()
+				if !ok {
+					return
+				}
+				scanTarget(sc, task, resultsChan)
+				atomic.AddInt64(&scannedCount, 1)
+				queue.taskDone()
 			}
 		}()
 	}
 
+	var outputFile *os.File
+	if config.outputFile != "" {
+		outputFile, err = os.Create(config.outputFile)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer outputFile.Close()
+	} else {
+		log.Fatal("Output filename is required when specifying an output format")
+	}
+
+	// json/xml need the full result set to produce one well-formed
+	// document, so they're buffered; text/csv/jsonl stream each row as it
+	// arrives instead of holding the whole scan in memory.
+	streaming := config.outputFormat == "csv" || config.outputFormat == "jsonl" || config.outputFormat == "text"
+
 	var results []ScanResult
+	var writer *resultWriter
+	if streaming {
+		writer = newResultWriter(config.outputFormat, outputFile)
+	}
+
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for result := range resultsChan {
-			results = append(results, result)
+			if streaming {
+				if err := writer.write(result); err != nil {
+					fmt.Printf("Failed to write result for %s: %s\n", result.URL, err)
+				}
+			} else {
+				results = append(results, result)
+			}
 		}
 	}()
 
 	startTime := time.Now()
 
-	for _, path := range paths {
-		tasksChan <- path
+	if len(paths) == 0 {
+		queue.closeEmpty()
+	} else {
+		queue.feederBegin()
+		for _, path := range paths {
+			enqueueIfNew(queue, &visited, scanTask{path: path, depth: 0})
+		}
+		queue.feederEnd()
 	}
-	close(tasksChan)
 
-	wg.Wait()
+	workers.Wait()
 	close(resultsChan)
+	<-done
 
 	duration := time.Since(startTime)
-	fmt.Printf("Checked %d URLs in %s using %d goroutines\n", len(paths), duration, config.concurrent)
-
-	var outputFile *os.File
-	if config.outputFile != "" {
-		outputFile, err = os.Create(config.outputFile)
-		if err != nil {
-			log.Fatalf("Failed to create output file: %v", err)
-		}
-		defer outputFile.Close()
-	} else {
-		log.Fatal("Output filename is required when specifying an output format")
-	}
+	fmt.Printf("Checked %d URLs in %s using %d goroutines\n", atomic.LoadInt64(&scannedCount), duration, config.concurrent)
 
 	switch config.outputFormat {
 	case "json":
@@ -151,14 +289,6 @@ func runScanner(config ScannerConfig) {
 			log.Fatalf("Failed to generate XML output: %v", err)
 		}
 		outputFile.WriteString(xml.Header + string(output))
-	default: // text
-		for _, result := range results {
-			if result.ErrorMessage != "" {
-				outputFile.WriteString(result.ErrorMessage + "\n")
-			} else {
-				outputFile.WriteString(fmt.Sprintf("URL: %s, Status: %s, HTTP Status Code: %d\n", result.URL, result.Status, result.StatusCode))
-			}
-		}
 	}
 
 	fmt.Printf("Saved results to %s\n", config.outputFile)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"io"
 	"os"
 )
 
@@ -22,3 +23,10 @@ func loadPaths(filePath string) ([]string, error) {
 	}
 	return paths, nil
 }
+
+// readBoundedBody reads up to limit bytes of body and closes it.
+func readBoundedBody(body io.ReadCloser, limit int64) []byte {
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, limit))
+	return data
+}